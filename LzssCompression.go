@@ -1,5 +1,45 @@
 package lzss
 
+// EntropyCoding selects how LZSS tokens are serialized once the match
+// search has produced them.
+type EntropyCoding int
+
+const (
+	// EntropyNone emits fixed-width literals and (position,length) pairs,
+	// byte-for-byte identical to the original wire format. This is the
+	// default.
+	EntropyNone EntropyCoding = iota
+	// EntropyStaticHuffman Huffman-codes literals, match lengths and
+	// position high nibbles with a fixed canonical code derived only from
+	// the alphabet sizes, so encoder and decoder agree on it without any
+	// header being transmitted.
+	EntropyStaticHuffman
+	// EntropyDynamicHuffman Huffman-codes each block of tokens with a
+	// code built from that block's own symbol frequencies, transmitting
+	// the code-length tables ahead of the tokens.
+	EntropyDynamicHuffman
+)
+
+// Strategy selects how runLZSS chooses between emitting a literal and
+// emitting a back-reference at each position.
+type Strategy int
+
+const (
+	// StrategyGreedy always takes the longest match found at the current
+	// position, exactly like the original algorithm. This is the
+	// default and does not change the wire format.
+	StrategyGreedy Strategy = iota
+	// StrategyLazy defers a match by one position to check whether the
+	// next position yields a strictly longer one, DEFLATE/zlib-style;
+	// this can improve the ratio at the cost of an extra tree search per
+	// position.
+	StrategyLazy
+	// StrategyHuffmanOnly never emits back-references, only literals.
+	// Useful for payloads that are already compressed, where searching
+	// for matches burns time without improving the ratio.
+	StrategyHuffmanOnly
+)
+
 // LzssSettings and DefaultSettings implementations remain unchanged
 type LzssSettings struct {
 	FrameSize      int
@@ -7,6 +47,12 @@ type LzssSettings struct {
 	FrameInitPos   int
 	MaxMatchLength int
 	MinMatchLength int
+	EntropyCoding  EntropyCoding
+	Strategy       Strategy
+	// MaxChainLength bounds how many tree nodes insertNode will traverse
+	// while searching for a match, mirroring zlib's max_chain_length. 0
+	// means unlimited (the original, unbounded search).
+	MaxChainLength int
 }
 
 func DefaultSettings() *LzssSettings {
@@ -16,23 +62,28 @@ func DefaultSettings() *LzssSettings {
 		FrameInitPos:   0xFEE,
 		MaxMatchLength: 0x12,
 		MinMatchLength: 2,
+		EntropyCoding:  EntropyNone,
+		Strategy:       StrategyGreedy,
+		MaxChainLength: 0,
 	}
 }
 
 // LZSSEncoder implements LZSS compression
 type LZSSEncoder struct {
-	settings      *LzssSettings
-	N             int    // Size of ring buffer
-	F             int    // Upper limit for match_length
-	THRESHOLD     int    // Minimum length threshold for encoding
-	NIL           int    // Index for root of binary search trees
-	PADDING       byte   // Padding character
-	textBuf       []byte // Ring buffer
-	lchild        []int  // Left children
-	rchild        []int  // Right children
-	parent        []int  // Parent nodes
-	matchPosition int    // Current match position
-	matchLength   int    // Current match length
+	settings       *LzssSettings
+	N              int    // Size of ring buffer
+	F              int    // Upper limit for match_length
+	THRESHOLD      int    // Minimum length threshold for encoding
+	NIL            int    // Index for root of binary search trees
+	PADDING        byte   // Padding character
+	textBuf        []byte // Ring buffer
+	lchild         []int  // Left children
+	rchild         []int  // Right children
+	parent         []int  // Parent nodes
+	matchPosition  int    // Current match position
+	matchLength    int    // Current match length
+	strategy       Strategy
+	maxChainLength int
 }
 
 // NewEncoder creates a new LZSS encoder with given settings
@@ -42,12 +93,14 @@ func NewEncoder(settings *LzssSettings) *LZSSEncoder {
 	}
 
 	e := &LZSSEncoder{
-		settings:  settings,
-		N:         settings.FrameSize,
-		F:         settings.MaxMatchLength,
-		THRESHOLD: settings.MinMatchLength,
-		NIL:       settings.FrameSize,
-		PADDING:   settings.FrameFill,
+		settings:       settings,
+		N:              settings.FrameSize,
+		F:              settings.MaxMatchLength,
+		THRESHOLD:      settings.MinMatchLength,
+		NIL:            settings.FrameSize,
+		PADDING:        settings.FrameFill,
+		strategy:       settings.Strategy,
+		maxChainLength: settings.MaxChainLength,
 	}
 
 	// Initialize buffers
@@ -64,6 +117,19 @@ func NewEncoder(settings *LzssSettings) *LZSSEncoder {
 	return e
 }
 
+// Reset reinitializes the encoder to the same state NewEncoder would
+// produce for the same settings, without reallocating its ring buffer or
+// trees, so it can be pulled from a pool and reused across Compress
+// calls.
+func (e *LZSSEncoder) Reset() {
+	for i := range e.textBuf {
+		e.textBuf[i] = e.PADDING
+	}
+	e.initState()
+	e.matchPosition = 0
+	e.matchLength = 0
+}
+
 func (e *LZSSEncoder) initState() {
 	// Initialize encoding state and trees
 	for i := e.N + 1; i < e.N+257; i++ {
@@ -85,6 +151,7 @@ func (e *LZSSEncoder) insertNode(r int) {
 	e.lchild[r] = e.NIL
 	e.matchLength = 0
 
+	chain := 0
 	for {
 		if cmp >= 0 {
 			if e.rchild[p] != e.NIL {
@@ -120,6 +187,11 @@ func (e *LZSSEncoder) insertNode(r int) {
 				break
 			}
 		}
+
+		chain++
+		if e.maxChainLength > 0 && chain >= e.maxChainLength {
+			break
+		}
 	}
 
 	e.parent[r] = e.parent[p]
@@ -171,24 +243,27 @@ func (e *LZSSEncoder) deleteNode(p int) {
 	e.parent[p] = e.NIL
 }
 
-// Compress compresses input data using LZSS algorithm
-func Compress(data []byte, settings *LzssSettings) []byte {
+// runLZSS drives the binary-tree match search over data, calling emit once
+// per token: emit(false, literalByte, 0, 0) for a literal, or
+// emit(true, 0, matchPosition, matchLength) for a back-reference. It holds
+// no opinion on how tokens are serialized, which lets both the fixed-field
+// wire format and the entropy-coded formats share the same search.
+func runLZSS(encoder *LZSSEncoder, data []byte, emit func(isMatch bool, lit byte, pos, length int)) {
 	if len(data) == 0 {
-		return nil
+		return
 	}
 
-	if settings == nil {
-		settings = DefaultSettings()
+	switch encoder.strategy {
+	case StrategyHuffmanOnly:
+		for _, c := range data {
+			emit(false, c, 0, 0)
+		}
+		return
+	case StrategyLazy:
+		runLZSSLazy(encoder, data, emit)
+		return
 	}
 
-	encoder := NewEncoder(settings)
-	codeBuf := make([]byte, 17)
-	compressed := make([]byte, 0)
-
-	codeBuf[0] = 0
-	codeBufPtr := 1
-	mask := uint16(1)
-
 	s := 0
 	r := encoder.N - encoder.F
 
@@ -202,7 +277,7 @@ func Compress(data []byte, settings *LzssSettings) []byte {
 	}
 
 	if length == 0 {
-		return nil
+		return
 	}
 
 	// Insert initial strings
@@ -218,24 +293,9 @@ func Compress(data []byte, settings *LzssSettings) []byte {
 
 		if encoder.matchLength <= encoder.THRESHOLD {
 			encoder.matchLength = 1
-			codeBuf[0] |= byte(mask)
-			codeBuf[codeBufPtr] = encoder.textBuf[r]
-			codeBufPtr++
+			emit(false, encoder.textBuf[r], 0, 0)
 		} else {
-			codeBuf[codeBufPtr] = byte(encoder.matchPosition & 0xFF)
-			codeBufPtr++
-			codeBuf[codeBufPtr] = byte(((encoder.matchPosition >> 4) & 0xF0) |
-				(encoder.matchLength - (encoder.THRESHOLD + 1)))
-			codeBufPtr++
-		}
-
-		mask <<= 1
-
-		if mask == 0x100 {
-			compressed = append(compressed, codeBuf[:codeBufPtr]...)
-			codeBuf[0] = 0
-			codeBufPtr = 1
-			mask = 1
+			emit(true, 0, encoder.matchPosition, encoder.matchLength)
 		}
 
 		lastMatchLength := encoder.matchLength
@@ -269,6 +329,63 @@ func Compress(data []byte, settings *LzssSettings) []byte {
 			i++
 		}
 	}
+}
+
+// Compress compresses input data using the LZSS algorithm. With the
+// default settings (EntropyCoding: EntropyNone) it emits the original
+// fixed-width wire format; otherwise it Huffman-codes tokens as described
+// by settings.EntropyCoding.
+func Compress(data []byte, settings *LzssSettings) []byte {
+	return AppendCompress(nil, data, settings)
+}
+
+// AppendCompress compresses data the same way Compress does and appends
+// the result to dst, returning the extended slice. This lets callers
+// reuse an output buffer across calls instead of letting Compress
+// allocate a fresh one every time, matching the Append convention used by
+// packages like encoding/hex and encoding/json.
+func AppendCompress(dst, data []byte, settings *LzssSettings) []byte {
+	if len(data) == 0 {
+		return dst
+	}
+
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+
+	if settings.EntropyCoding != EntropyNone {
+		return append(dst, compressEntropy(data, settings)...)
+	}
+
+	encoder := acquireEncoder(settings)
+	defer releaseEncoder(settings, encoder)
+	codeBuf := make([]byte, 17)
+	compressed := dst
+
+	codeBuf[0] = 0
+	codeBufPtr := 1
+	mask := uint16(1)
+
+	runLZSS(encoder, data, func(isMatch bool, lit byte, pos, length int) {
+		if !isMatch {
+			codeBuf[0] |= byte(mask)
+			codeBuf[codeBufPtr] = lit
+			codeBufPtr++
+		} else {
+			codeBuf[codeBufPtr] = byte(pos & 0xFF)
+			codeBufPtr++
+			codeBuf[codeBufPtr] = byte(((pos >> 4) & 0xF0) | (length - (encoder.THRESHOLD + 1)))
+			codeBufPtr++
+		}
+
+		mask <<= 1
+		if mask == 0x100 {
+			compressed = append(compressed, codeBuf[:codeBufPtr]...)
+			codeBuf[0] = 0
+			codeBufPtr = 1
+			mask = 1
+		}
+	})
 
 	if codeBufPtr > 1 {
 		compressed = append(compressed, codeBuf[:codeBufPtr]...)
@@ -287,6 +404,10 @@ func Decompress(compressedData []byte, settings *LzssSettings) []byte {
 		settings = DefaultSettings()
 	}
 
+	if settings.EntropyCoding != EntropyNone {
+		return decompressEntropy(compressedData, settings)
+	}
+
 	textBuf := make([]byte, settings.FrameSize+settings.MaxMatchLength-1)
 	for i := range textBuf {
 		textBuf[i] = settings.FrameFill
@@ -0,0 +1,384 @@
+package lzss
+
+// This file implements the two EntropyCoding modes: literals, match
+// lengths and match-position high nibbles are each Huffman-coded on their
+// own canonical tree instead of being written as fixed-width fields.
+//
+// Wire format (independent of, and selected instead of, the fixed-field
+// format in LzssCompression.go):
+//
+//   - EntropyStaticHuffman: exactly one block covering the whole input.
+//     No header - both sides derive the same canonical code from the
+//     alphabet sizes alone.
+//   - EntropyDynamicHuffman: one or more blocks of up to
+//     entropyBlockTokens tokens each. Every block starts with its own
+//     code-length tables (see writeLengthTable/readLengthTable) followed
+//     by the bit-packed tokens.
+//
+// Within a block, tokens are a bitstream terminated by an end-of-block
+// symbol on the literal tree: a literal byte (0-255), the end-of-block
+// marker (256), or the match escape (257) which is followed by a length
+// symbol on the length tree and a position high nibble on the position
+// tree plus 8 raw bits for the position's low byte.
+const (
+	entropyLitEOB       = 256
+	entropyLitMatch     = 257
+	entropyLitSize      = 258
+	entropyPosAlphaSize = 16 // matches the 4-bit position nibble the fixed-field format packs into the high nibble of its second byte
+
+	// entropyBlockTokens bounds how many tokens EntropyDynamicHuffman
+	// buffers before flushing a block and rebuilding its Huffman trees,
+	// keeping the code adapted to local statistics.
+	entropyBlockTokens = 32 * 1024
+
+	clAlphaSize = 19 // DEFLATE-style code-length alphabet
+	clLimit     = 7  // code-length code lengths are transmitted as fixed 3-bit fields
+)
+
+// lzssToken is one literal or back-reference produced by runLZSS, buffered
+// until a block is ready to be Huffman-coded.
+type lzssToken struct {
+	isMatch bool
+	lit     byte
+	pos     int
+	length  int // raw match length (threshold+1..F), only valid if isMatch
+}
+
+// entropyAlphabet describes the three per-stream alphabets entropy coding
+// uses and, for EntropyStaticHuffman, the fixed code lengths derived from
+// their sizes alone.
+type entropyAlphabet struct {
+	lenAlphaSize int
+	threshold    int
+
+	staticLitLen []int
+	staticLenLen []int
+	staticPosLen []int
+}
+
+func newEntropyAlphabet(settings *LzssSettings) *entropyAlphabet {
+	a := &entropyAlphabet{
+		lenAlphaSize: settings.MaxMatchLength - settings.MinMatchLength,
+		threshold:    settings.MinMatchLength,
+	}
+	a.staticLitLen = staticLengths(entropyLitSize)
+	a.staticLenLen = staticLengths(a.lenAlphaSize)
+	a.staticPosLen = staticLengths(entropyPosAlphaSize)
+	return a
+}
+
+// staticLengths builds the canonical code EntropyStaticHuffman uses for an
+// n-symbol alphabet: a length-limited Huffman code over uniform
+// frequencies, which both encoder and decoder can reproduce without a
+// transmitted header.
+func staticLengths(n int) []int {
+	freqs := make([]int, n)
+	for i := range freqs {
+		freqs[i] = 1
+	}
+	return buildHuffmanLengths(freqs, huffmanMaxLength)
+}
+
+// compressEntropy implements Compress for settings.EntropyCoding !=
+// EntropyNone.
+func compressEntropy(data []byte, settings *LzssSettings) []byte {
+	encoder := acquireEncoder(settings)
+	defer releaseEncoder(settings, encoder)
+	alpha := newEntropyAlphabet(settings)
+
+	var out []byte
+	tokens := make([]lzssToken, 0, entropyBlockTokens)
+
+	flush := func() {
+		if len(tokens) == 0 {
+			return
+		}
+		if settings.EntropyCoding == EntropyDynamicHuffman {
+			out = append(out, encodeDynamicBlock(tokens, alpha)...)
+		} else {
+			out = append(out, encodeStaticBlock(tokens, alpha)...)
+		}
+		tokens = tokens[:0]
+	}
+
+	runLZSS(encoder, data, func(isMatch bool, lit byte, pos, length int) {
+		if isMatch {
+			tokens = append(tokens, lzssToken{isMatch: true, pos: pos, length: length})
+		} else {
+			tokens = append(tokens, lzssToken{lit: lit})
+		}
+		if settings.EntropyCoding == EntropyDynamicHuffman && len(tokens) >= entropyBlockTokens {
+			flush()
+		}
+	})
+	flush()
+
+	return out
+}
+
+// decompressEntropy implements Decompress for settings.EntropyCoding !=
+// EntropyNone.
+func decompressEntropy(compressedData []byte, settings *LzssSettings) []byte {
+	alpha := newEntropyAlphabet(settings)
+	br := &bitReader{data: compressedData}
+
+	N := settings.FrameSize
+	textBuf := make([]byte, N+settings.MaxMatchLength-1)
+	for i := range textBuf {
+		textBuf[i] = settings.FrameFill
+	}
+	pos := N - settings.MaxMatchLength
+
+	var out []byte
+
+	for br.pos < len(br.data) {
+		var litLen, lenLen, posLen []int
+		if settings.EntropyCoding == EntropyDynamicHuffman {
+			litLen = readLengthTable(br, entropyLitSize)
+			lenLen = readLengthTable(br, alpha.lenAlphaSize)
+			posLen = readLengthTable(br, entropyPosAlphaSize)
+			if br.eof {
+				break
+			}
+		} else {
+			litLen, lenLen, posLen = alpha.staticLitLen, alpha.staticLenLen, alpha.staticPosLen
+		}
+
+		litDec := newCanonicalDecoder(litLen)
+		lenDec := newCanonicalDecoder(lenLen)
+		posDec := newCanonicalDecoder(posLen)
+
+		for {
+			sym := litDec.decode(br)
+			if br.eof || sym == entropyLitEOB || sym < 0 {
+				break
+			}
+
+			if sym != entropyLitMatch {
+				c := byte(sym)
+				out = append(out, c)
+				textBuf[pos] = c
+				pos = (pos + 1) & (N - 1)
+				continue
+			}
+
+			lengthCode := lenDec.decode(br)
+			nibble := posDec.decode(br)
+			low := br.readBits(8)
+			if br.eof || lengthCode < 0 || nibble < 0 {
+				break
+			}
+			matchLength := lengthCode + alpha.threshold + 1
+			matchPos := (nibble << 8) | low
+
+			for k := 0; k < matchLength; k++ {
+				c := textBuf[(matchPos+k)&(N-1)]
+				out = append(out, c)
+				textBuf[pos] = c
+				pos = (pos + 1) & (N - 1)
+			}
+		}
+		br.align()
+
+		if br.eof || settings.EntropyCoding != EntropyDynamicHuffman {
+			break
+		}
+	}
+
+	return out
+}
+
+// encodeStaticBlock encodes every token with the fixed canonical codes
+// derived from the alphabet sizes alone.
+func encodeStaticBlock(tokens []lzssToken, alpha *entropyAlphabet) []byte {
+	bw := &bitWriter{}
+	writeTokens(bw, tokens, alpha, alpha.staticLitLen, alpha.staticLenLen, alpha.staticPosLen)
+	bw.align()
+	return bw.out
+}
+
+// encodeDynamicBlock builds a canonical code from this block's own token
+// frequencies, transmits the code-length tables, and then encodes the
+// tokens against them.
+func encodeDynamicBlock(tokens []lzssToken, alpha *entropyAlphabet) []byte {
+	litFreq := make([]int, entropyLitSize)
+	lenFreq := make([]int, alpha.lenAlphaSize)
+	posFreq := make([]int, entropyPosAlphaSize)
+	litFreq[entropyLitEOB] = 1 // guarantee the terminator is always encodable
+
+	for _, t := range tokens {
+		if t.isMatch {
+			litFreq[entropyLitMatch]++
+			lenFreq[t.length-(alpha.threshold+1)]++
+			posFreq[(t.pos>>8)&0xF]++
+		} else {
+			litFreq[int(t.lit)]++
+		}
+	}
+
+	litLen := buildHuffmanLengths(litFreq, huffmanMaxLength)
+	lenLen := buildHuffmanLengths(lenFreq, huffmanMaxLength)
+	posLen := buildHuffmanLengths(posFreq, huffmanMaxLength)
+
+	bw := &bitWriter{}
+	writeLengthTable(bw, litLen)
+	writeLengthTable(bw, lenLen)
+	writeLengthTable(bw, posLen)
+	writeTokens(bw, tokens, alpha, litLen, lenLen, posLen)
+	bw.align()
+	return bw.out
+}
+
+func writeTokens(bw *bitWriter, tokens []lzssToken, alpha *entropyAlphabet, litLen, lenLen, posLen []int) {
+	litCodes := canonicalCodesFromLengths(litLen)
+	lenCodes := canonicalCodesFromLengths(lenLen)
+	posCodes := canonicalCodesFromLengths(posLen)
+
+	for _, t := range tokens {
+		if t.isMatch {
+			bw.writeBits(uint32(litCodes[entropyLitMatch]), litLen[entropyLitMatch])
+
+			lc := t.length - (alpha.threshold + 1)
+			bw.writeBits(uint32(lenCodes[lc]), lenLen[lc])
+
+			nibble := (t.pos >> 8) & 0xF
+			bw.writeBits(uint32(posCodes[nibble]), posLen[nibble])
+			bw.writeBits(uint32(t.pos&0xFF), 8)
+		} else {
+			bw.writeBits(uint32(litCodes[t.lit]), litLen[t.lit])
+		}
+	}
+	bw.writeBits(uint32(litCodes[entropyLitEOB]), litLen[entropyLitEOB])
+}
+
+// clEntry is one symbol of a DEFLATE-style code-length run-length encoding:
+// sym is a code-length alphabet symbol (0-18), extra/bits its repeat count
+// payload, if any.
+type clEntry struct {
+	sym   int
+	extra int
+	bits  int
+}
+
+// rleCodeLengths run-length encodes a code-length table the same way
+// DEFLATE does: literal lengths 0-15 are emitted as-is, and runs of a
+// repeated nonzero length or of zeros are folded into symbols 16-18.
+func rleCodeLengths(lengths []int) []clEntry {
+	var entries []clEntry
+	n := len(lengths)
+
+	for i := 0; i < n; {
+		l := lengths[i]
+		runLen := 1
+		for i+runLen < n && lengths[i+runLen] == l {
+			runLen++
+		}
+
+		if l == 0 {
+			remaining := runLen
+			for remaining > 0 {
+				if remaining < 3 {
+					entries = append(entries, clEntry{sym: 0})
+					remaining--
+					continue
+				}
+				chunk := remaining
+				if chunk > 138 {
+					chunk = 138
+				}
+				if chunk <= 10 {
+					entries = append(entries, clEntry{sym: 17, extra: chunk - 3, bits: 3})
+				} else {
+					entries = append(entries, clEntry{sym: 18, extra: chunk - 11, bits: 7})
+				}
+				remaining -= chunk
+			}
+		} else {
+			entries = append(entries, clEntry{sym: l})
+			remaining := runLen - 1
+			for remaining > 0 {
+				if remaining < 3 {
+					entries = append(entries, clEntry{sym: l})
+					remaining--
+					continue
+				}
+				chunk := remaining
+				if chunk > 6 {
+					chunk = 6
+				}
+				entries = append(entries, clEntry{sym: 16, extra: chunk - 3, bits: 2})
+				remaining -= chunk
+			}
+		}
+
+		i += runLen
+	}
+
+	return entries
+}
+
+// writeLengthTable RLE-encodes lengths, Huffman-codes the resulting
+// code-length symbols, and writes: the 19 code-length code lengths as
+// fixed 3-bit fields, an entry count, then the RLE symbols themselves.
+func writeLengthTable(bw *bitWriter, lengths []int) {
+	entries := rleCodeLengths(lengths)
+
+	clFreq := make([]int, clAlphaSize)
+	for _, e := range entries {
+		clFreq[e.sym]++
+	}
+	clLen := buildHuffmanLengths(clFreq, clLimit)
+	for _, l := range clLen {
+		bw.writeBits(uint32(l), 3)
+	}
+
+	clCodes := canonicalCodesFromLengths(clLen)
+	bw.writeBits(uint32(len(entries)), 16)
+	for _, e := range entries {
+		bw.writeBits(uint32(clCodes[e.sym]), clLen[e.sym])
+		if e.bits > 0 {
+			bw.writeBits(uint32(e.extra), e.bits)
+		}
+	}
+}
+
+// readLengthTable is the mirror image of writeLengthTable, reconstructing
+// an alphaSize-symbol code-length table.
+func readLengthTable(br *bitReader, alphaSize int) []int {
+	clLen := make([]int, clAlphaSize)
+	for i := range clLen {
+		clLen[i] = br.readBits(3)
+	}
+	clDec := newCanonicalDecoder(clLen)
+
+	count := br.readBits(16)
+	lengths := make([]int, alphaSize)
+	pos, prev := 0, 0
+
+	for k := 0; k < count && pos < alphaSize; k++ {
+		sym := clDec.decode(br)
+		switch {
+		case sym >= 0 && sym < 16:
+			lengths[pos] = sym
+			prev = sym
+			pos++
+		case sym == 16:
+			repeat := br.readBits(2) + 3
+			for x := 0; x < repeat && pos < alphaSize; x++ {
+				lengths[pos] = prev
+				pos++
+			}
+		case sym == 17:
+			repeat := br.readBits(3) + 3
+			pos += repeat
+		case sym == 18:
+			repeat := br.readBits(7) + 11
+			pos += repeat
+		}
+	}
+
+	if pos > alphaSize {
+		lengths = lengths[:alphaSize]
+	}
+	return lengths
+}
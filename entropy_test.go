@@ -0,0 +1,53 @@
+package lzss
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func entropySettings(coding EntropyCoding) *LzssSettings {
+	s := DefaultSettings()
+	s.EntropyCoding = coding
+	return s
+}
+
+func TestEntropyRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 200)
+
+	for _, coding := range []EntropyCoding{EntropyStaticHuffman, EntropyDynamicHuffman} {
+		settings := entropySettings(coding)
+		compressed := Compress(data, settings)
+		got := Decompress(compressed, settings)
+		if !bytes.Equal(got, data) {
+			t.Fatalf("coding %v: round trip mismatch (got %d bytes, want %d)", coding, len(got), len(data))
+		}
+	}
+}
+
+// TestEntropyTruncatedInputTerminates guards against the canonical decode
+// loop trusting an exhausted bitReader to eventually produce an
+// end-of-block symbol: truncating the compressed stream at any point must
+// make decompressEntropy return promptly (with a best-effort, possibly
+// incomplete, result) rather than hang.
+func TestEntropyTruncatedInputTerminates(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 200)
+	settings := entropySettings(EntropyDynamicHuffman)
+	compressed := Compress(data, settings)
+
+	for cut := 1; cut <= len(compressed); cut += 7 {
+		truncated := compressed[:len(compressed)-cut]
+
+		done := make(chan struct{})
+		go func() {
+			Decompress(truncated, settings)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Decompress hung on input truncated by %d bytes", cut)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package lzss
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// ErrChecksum is returned by FrameReader when the trailing CRC-32 (or the
+// original length next to it) doesn't match the decompressed payload,
+// indicating a corrupt frame.
+var ErrChecksum = errors.New("lzss: frame: checksum mismatch")
+
+// ErrHeader is returned by NewFrameReader when the input doesn't start
+// with the frame magic, and by FrameReader.Read when the data after the
+// header is too short to hold a trailer.
+var ErrHeader = errors.New("lzss: frame: invalid header")
+
+const (
+	frameMagic   = "LZSS"
+	frameVersion = 1
+)
+
+// FrameHeader carries the optional, self-describing metadata a frame can
+// carry alongside the settings needed to decompress it.
+type FrameHeader struct {
+	Name    string
+	ModTime time.Time // zero value means absent
+}
+
+// FrameWriter buffers the plaintext written to it and, on Close,
+// compresses it with settings and writes a self-contained frame to the
+// underlying writer: magic, version, a settings block, the optional
+// header fields, the compressed payload, and a trailing IEEE CRC-32 plus
+// original length. It does not close the underlying writer.
+type FrameWriter struct {
+	w        io.Writer
+	settings *LzssSettings
+	header   FrameHeader
+	buf      []byte
+	closed   bool
+}
+
+// NewFrameWriter returns a FrameWriter that writes a framed, compressed
+// form of everything written to it to w, using settings (or
+// DefaultSettings if nil) and the given header metadata.
+func NewFrameWriter(w io.Writer, settings *LzssSettings, header FrameHeader) *FrameWriter {
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	return &FrameWriter{w: w, settings: settings, header: header}
+}
+
+func (fw *FrameWriter) Write(p []byte) (int, error) {
+	if fw.closed {
+		return 0, errClosedWriter
+	}
+	fw.buf = append(fw.buf, p...)
+	return len(p), nil
+}
+
+// Close compresses everything written so far and writes the complete
+// frame - header, payload and trailer - to the underlying writer.
+func (fw *FrameWriter) Close() error {
+	if fw.closed {
+		return nil
+	}
+	fw.closed = true
+
+	if err := writeFrameHeader(fw.w, fw.settings, fw.header); err != nil {
+		return err
+	}
+	if _, err := fw.w.Write(Compress(fw.buf, fw.settings)); err != nil {
+		return err
+	}
+
+	var trailer [12]byte
+	binary.LittleEndian.PutUint32(trailer[0:4], crc32.ChecksumIEEE(fw.buf))
+	binary.LittleEndian.PutUint64(trailer[4:12], uint64(len(fw.buf)))
+	_, err := fw.w.Write(trailer[:])
+	return err
+}
+
+func writeFrameHeader(w io.Writer, settings *LzssSettings, header FrameHeader) error {
+	buf := append([]byte(nil), frameMagic...)
+	buf = append(buf, frameVersion)
+
+	buf = appendUvarint(buf, uint64(settings.FrameSize))
+	buf = appendUvarint(buf, uint64(settings.MaxMatchLength))
+	buf = appendUvarint(buf, uint64(settings.MinMatchLength))
+	buf = append(buf, settings.FrameFill)
+	buf = appendUvarint(buf, uint64(settings.FrameInitPos))
+	// EntropyCoding isn't in the request's field list, but the decoder
+	// can't reconstruct usable settings without it, so it rides along
+	// with the rest of the settings block.
+	buf = append(buf, byte(settings.EntropyCoding))
+
+	buf = appendUvarint(buf, uint64(len(header.Name)))
+	buf = append(buf, header.Name...)
+
+	var modTime uint64
+	if !header.ModTime.IsZero() {
+		modTime = uint64(header.ModTime.Unix())
+	}
+	buf = appendUvarint(buf, modTime)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+// FrameReader reads a frame written by FrameWriter: it parses and
+// validates the header on construction, then decompresses and verifies
+// the payload against its trailer the first time Read is called.
+type FrameReader struct {
+	r        *bufio.Reader
+	settings *LzssSettings
+	header   FrameHeader
+
+	started bool
+	data    []byte
+	pos     int
+	err     error
+}
+
+// NewFrameReader reads and validates a frame's header from r, returning
+// the settings and metadata it describes. It returns ErrHeader if the
+// magic doesn't match, and an error naming the version if it isn't one
+// this package understands.
+func NewFrameReader(r io.Reader) (*FrameReader, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(frameMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != frameMagic {
+		return nil, ErrHeader
+	}
+
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != frameVersion {
+		return nil, fmt.Errorf("lzss: frame: unsupported version %d", version)
+	}
+
+	settings := DefaultSettings()
+	if settings.FrameSize, err = readUvarintInt(br); err != nil {
+		return nil, err
+	}
+	if settings.MaxMatchLength, err = readUvarintInt(br); err != nil {
+		return nil, err
+	}
+	if settings.MinMatchLength, err = readUvarintInt(br); err != nil {
+		return nil, err
+	}
+	if settings.FrameFill, err = br.ReadByte(); err != nil {
+		return nil, err
+	}
+	if settings.FrameInitPos, err = readUvarintInt(br); err != nil {
+		return nil, err
+	}
+	entropy, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	settings.EntropyCoding = EntropyCoding(entropy)
+
+	nameLen, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	name := make([]byte, nameLen)
+	if _, err := io.ReadFull(br, name); err != nil {
+		return nil, err
+	}
+
+	modUnix, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+
+	header := FrameHeader{Name: string(name)}
+	if modUnix != 0 {
+		header.ModTime = time.Unix(int64(modUnix), 0)
+	}
+
+	return &FrameReader{r: br, settings: settings, header: header}, nil
+}
+
+func readUvarintInt(r io.ByteReader) (int, error) {
+	v, err := binary.ReadUvarint(r)
+	return int(v), err
+}
+
+// Settings returns the settings reconstructed from the frame header, so
+// callers don't have to remember the exact settings used at compress
+// time.
+func (fr *FrameReader) Settings() *LzssSettings {
+	return fr.settings
+}
+
+// Header returns the frame's optional metadata.
+func (fr *FrameReader) Header() FrameHeader {
+	return fr.header
+}
+
+func (fr *FrameReader) Read(p []byte) (int, error) {
+	if !fr.started {
+		fr.started = true
+		fr.err = fr.decode()
+	}
+	if fr.err != nil {
+		return 0, fr.err
+	}
+	if fr.pos >= len(fr.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, fr.data[fr.pos:])
+	fr.pos += n
+	return n, nil
+}
+
+// maxFrameExpansionRatio bounds how much larger the trailer's claimed
+// original length may be than the compressed payload before decode
+// refuses to even attempt decompression. LZSS (with or without entropy
+// coding) can't usefully expand data past roughly this ratio - the
+// cheapest possible match token is still a handful of bits for at most
+// MaxMatchLength bytes - so a wantLen far beyond it means the header or
+// trailer is corrupt or the frame was truncated, not that decompression
+// would succeed. Checking this before calling Decompress avoids handing
+// a decoder attacker-controlled settings (including EntropyCoding, read
+// straight off the wire in NewFrameReader) and an unbounded/corrupt
+// payload without first ruling out the obviously-impossible case.
+const maxFrameExpansionRatio = 256
+
+// decode reads the rest of the frame, splits off the trailer, and
+// decompresses and verifies the payload against it.
+func (fr *FrameReader) decode() error {
+	rest, err := io.ReadAll(fr.r)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 12 {
+		return ErrHeader
+	}
+
+	payload := rest[:len(rest)-12]
+	trailer := rest[len(rest)-12:]
+	wantCRC := binary.LittleEndian.Uint32(trailer[0:4])
+	wantLen := binary.LittleEndian.Uint64(trailer[4:12])
+
+	if wantLen > uint64(len(payload)+64)*maxFrameExpansionRatio {
+		return ErrChecksum
+	}
+
+	fr.data = Decompress(payload, fr.settings)
+	if uint64(len(fr.data)) != wantLen || crc32.ChecksumIEEE(fr.data) != wantCRC {
+		return ErrChecksum
+	}
+	return nil
+}
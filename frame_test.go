@@ -0,0 +1,94 @@
+package lzss
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 200)
+	settings := DefaultSettings()
+
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf, settings, FrameHeader{Name: "test.txt"})
+	if _, err := fw.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fr, err := NewFrameReader(&buf)
+	if err != nil {
+		t.Fatalf("NewFrameReader: %v", err)
+	}
+	if fr.Header().Name != "test.txt" {
+		t.Fatalf("Header().Name = %q, want %q", fr.Header().Name, "test.txt")
+	}
+
+	got, err := io.ReadAll(fr)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("round trip mismatch (got %d bytes, want %d)", len(got), len(data))
+	}
+}
+
+func TestFrameReaderRejectsBadMagic(t *testing.T) {
+	_, err := NewFrameReader(bytes.NewReader([]byte("not a frame at all")))
+	if err != ErrHeader {
+		t.Fatalf("err = %v, want ErrHeader", err)
+	}
+}
+
+// TestFrameReaderTruncatedPayload exercises a frame whose compressed
+// payload was cut short: Read must return an error (ErrChecksum, or
+// ErrHeader once too little is left for the trailer) promptly instead of
+// hanging or panicking, for truncation points every 11 bytes and for both
+// wire formats.
+func TestFrameReaderTruncatedPayload(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 200)
+
+	for _, coding := range []EntropyCoding{EntropyNone, EntropyDynamicHuffman} {
+		settings := DefaultSettings()
+		settings.EntropyCoding = coding
+
+		var buf bytes.Buffer
+		fw := NewFrameWriter(&buf, settings, FrameHeader{})
+		if _, err := fw.Write(data); err != nil {
+			t.Fatalf("coding %v: Write: %v", coding, err)
+		}
+		if err := fw.Close(); err != nil {
+			t.Fatalf("coding %v: Close: %v", coding, err)
+		}
+		full := buf.Bytes()
+
+		for cut := 1; cut < len(full)-12; cut += 11 {
+			truncated := full[:len(full)-cut]
+
+			fr, err := NewFrameReader(bytes.NewReader(truncated))
+			if err != nil {
+				// Truncation landed inside the header itself.
+				continue
+			}
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := io.ReadAll(fr)
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if err == nil {
+					t.Fatalf("coding %v, cut %d: expected an error on truncated frame, got nil", coding, cut)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("coding %v, cut %d: FrameReader.Read hung on truncated payload", coding, cut)
+			}
+		}
+	}
+}
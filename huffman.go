@@ -0,0 +1,341 @@
+package lzss
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// huffmanMaxLength is the maximum canonical Huffman code length this
+// package will ever produce, matching DEFLATE's length limit.
+const huffmanMaxLength = 15
+
+// bitWriter packs bits into a growing byte slice, most-significant bit of
+// each written value first, least-significant bit of the stream first -
+// bitReader consumes bits in the same order so the two are mirror images
+// of each other.
+type bitWriter struct {
+	out  []byte
+	cur  byte
+	nbit uint
+}
+
+func (bw *bitWriter) writeBit(b uint32) {
+	bw.cur |= byte(b&1) << bw.nbit
+	bw.nbit++
+	if bw.nbit == 8 {
+		bw.out = append(bw.out, bw.cur)
+		bw.cur = 0
+		bw.nbit = 0
+	}
+}
+
+// writeBits writes the nbits low bits of value, most significant bit first.
+func (bw *bitWriter) writeBits(value uint32, nbits int) {
+	for i := nbits - 1; i >= 0; i-- {
+		bw.writeBit((value >> uint(i)) & 1)
+	}
+}
+
+// align pads the current byte with zero bits so the next write starts on a
+// byte boundary.
+func (bw *bitWriter) align() {
+	if bw.nbit != 0 {
+		bw.out = append(bw.out, bw.cur)
+		bw.cur = 0
+		bw.nbit = 0
+	}
+}
+
+// bitReader is the mirror image of bitWriter. Once the underlying data is
+// exhausted, readBit keeps returning 0 (so callers that don't check eof
+// don't need a nil check on every call) but also sets eof, so callers
+// that must detect truncated/corrupt input - such as the token decode
+// loop in decompressEntropy, which would otherwise trust an all-zero bit
+// pattern to eventually decode an end-of-block symbol - can bail instead
+// of looping forever.
+type bitReader struct {
+	data []byte
+	pos  int
+	nbit uint
+	eof  bool
+}
+
+func (br *bitReader) readBit() uint32 {
+	if br.pos >= len(br.data) {
+		br.eof = true
+		return 0
+	}
+	b := (br.data[br.pos] >> br.nbit) & 1
+	br.nbit++
+	if br.nbit == 8 {
+		br.nbit = 0
+		br.pos++
+	}
+	return uint32(b)
+}
+
+func (br *bitReader) readBits(nbits int) int {
+	v := 0
+	for i := 0; i < nbits; i++ {
+		v = (v << 1) | int(br.readBit())
+	}
+	return v
+}
+
+func (br *bitReader) align() {
+	if br.nbit != 0 {
+		br.nbit = 0
+		br.pos++
+	}
+}
+
+// canonicalCodesFromLengths assigns canonical Huffman codes to symbols
+// given their code lengths, following the construction in RFC 1951 3.2.2:
+// symbols are ordered first by length, then by symbol value, and codes
+// increase accordingly. Symbols with length 0 are unused and get code 0.
+func canonicalCodesFromLengths(lengths []int) []uint16 {
+	maxLen := 0
+	for _, l := range lengths {
+		if l > maxLen {
+			maxLen = l
+		}
+	}
+	codes := make([]uint16, len(lengths))
+	if maxLen == 0 {
+		return codes
+	}
+
+	blCount := make([]int, maxLen+1)
+	for _, l := range lengths {
+		if l > 0 {
+			blCount[l]++
+		}
+	}
+
+	nextCode := make([]int, maxLen+1)
+	code := 0
+	for bits := 1; bits <= maxLen; bits++ {
+		code = (code + blCount[bits-1]) << 1
+		nextCode[bits] = code
+	}
+
+	for sym, l := range lengths {
+		if l > 0 {
+			codes[sym] = uint16(nextCode[l])
+			nextCode[l]++
+		}
+	}
+	return codes
+}
+
+// canonicalDecoder decodes symbols encoded with canonicalCodesFromLengths,
+// reading one bit at a time per the decoding algorithm in RFC 1951 3.2.2.
+type canonicalDecoder struct {
+	maxLen     int
+	counts     []int
+	firstCode  []int
+	firstIndex []int
+	symbols    []int
+}
+
+func newCanonicalDecoder(lengths []int) *canonicalDecoder {
+	maxLen := 0
+	for _, l := range lengths {
+		if l > maxLen {
+			maxLen = l
+		}
+	}
+	d := &canonicalDecoder{maxLen: maxLen}
+	if maxLen == 0 {
+		return d
+	}
+
+	counts := make([]int, maxLen+1)
+	for _, l := range lengths {
+		if l > 0 {
+			counts[l]++
+		}
+	}
+	d.counts = counts
+
+	type symLen struct{ sym, length int }
+	entries := make([]symLen, 0, len(lengths))
+	for sym, l := range lengths {
+		if l > 0 {
+			entries = append(entries, symLen{sym, l})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].length != entries[j].length {
+			return entries[i].length < entries[j].length
+		}
+		return entries[i].sym < entries[j].sym
+	})
+	d.symbols = make([]int, len(entries))
+	for i, e := range entries {
+		d.symbols[i] = e.sym
+	}
+
+	d.firstCode = make([]int, maxLen+1)
+	d.firstIndex = make([]int, maxLen+1)
+	code, index := 0, 0
+	for length := 1; length <= maxLen; length++ {
+		d.firstCode[length] = code
+		d.firstIndex[length] = index
+		index += counts[length]
+		code = (code + counts[length]) << 1
+	}
+	return d
+}
+
+// decode reads one symbol from br, or -1 if the bitstream is malformed.
+func (d *canonicalDecoder) decode(br *bitReader) int {
+	code := 0
+	for length := 1; length <= d.maxLen; length++ {
+		code = (code << 1) | int(br.readBit())
+		count := d.counts[length]
+		if count > 0 && code-d.firstCode[length] < count {
+			return d.symbols[d.firstIndex[length]+code-d.firstCode[length]]
+		}
+	}
+	return -1
+}
+
+// huffmanItem is a leaf or internal node queued while building a Huffman
+// tree; node indexes into the tree slice built alongside the heap.
+type huffmanItem struct {
+	freq int
+	node int
+}
+
+type huffmanHeap []huffmanItem
+
+func (h huffmanHeap) Len() int            { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool  { return h[i].freq < h[j].freq }
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(huffmanItem)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	*h = old[:n-1]
+	return it
+}
+
+type huffmanNode struct {
+	left, right int // indexes into the node slice, -1 for a leaf
+	sym         int
+}
+
+// buildHuffmanLengths computes a length-limited canonical Huffman code
+// length for every symbol with a nonzero frequency, using a standard
+// min-heap tree build followed by the overflow fix-up zlib's gen_bitlen
+// uses to bring the longest codes back under limit. Symbols with zero
+// frequency get length 0 (unused).
+func buildHuffmanLengths(freqs []int, limit int) []int {
+	lengths := make([]int, len(freqs))
+
+	var nodes []huffmanNode
+	h := &huffmanHeap{}
+	for sym, f := range freqs {
+		if f <= 0 {
+			continue
+		}
+		nodes = append(nodes, huffmanNode{left: -1, right: -1, sym: sym})
+		*h = append(*h, huffmanItem{freq: f, node: len(nodes) - 1})
+	}
+
+	switch len(*h) {
+	case 0:
+		return lengths
+	case 1:
+		lengths[nodes[(*h)[0].node].sym] = 1
+		return lengths
+	}
+
+	heap.Init(h)
+	for h.Len() > 1 {
+		a := heap.Pop(h).(huffmanItem)
+		b := heap.Pop(h).(huffmanItem)
+		nodes = append(nodes, huffmanNode{left: a.node, right: b.node, sym: -1})
+		heap.Push(h, huffmanItem{freq: a.freq + b.freq, node: len(nodes) - 1})
+	}
+
+	var walk func(idx, depth int)
+	walk = func(idx, depth int) {
+		n := nodes[idx]
+		if n.left == -1 && n.right == -1 {
+			lengths[n.sym] = depth
+			return
+		}
+		walk(n.left, depth+1)
+		walk(n.right, depth+1)
+	}
+	walk(heap.Pop(h).(huffmanItem).node, 0)
+
+	limitLengths(lengths, freqs, limit)
+	return lengths
+}
+
+// limitLengths brings a set of Huffman code lengths under limit using the
+// same fix-up zlib's gen_bitlen applies: pull the longest leaves down one
+// level at a time until nothing exceeds the limit, then hand out the
+// resulting length budget to symbols in descending frequency order so the
+// most common symbols keep the shortest codes.
+func limitLengths(lengths []int, freqs []int, limit int) {
+	maxLen := 0
+	for _, l := range lengths {
+		if l > maxLen {
+			maxLen = l
+		}
+	}
+	if maxLen <= limit {
+		return
+	}
+
+	blCount := make([]int, maxLen+2)
+	for _, l := range lengths {
+		if l > 0 {
+			blCount[l]++
+		}
+	}
+
+	overflow := 0
+	for l := maxLen; l > limit; l-- {
+		overflow += blCount[l]
+		blCount[l] = 0
+	}
+	blCount[limit] += overflow
+
+	for overflow > 0 {
+		l := limit - 1
+		for blCount[l] == 0 {
+			l--
+		}
+		blCount[l]--
+		blCount[l+1] += 2
+		blCount[limit]--
+		overflow -= 2
+	}
+
+	order := make([]int, 0, len(freqs))
+	for sym, f := range freqs {
+		if f > 0 {
+			order = append(order, sym)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if freqs[order[i]] != freqs[order[j]] {
+			return freqs[order[i]] > freqs[order[j]]
+		}
+		return order[i] < order[j]
+	})
+
+	idx := 0
+	for l := 1; l <= limit; l++ {
+		for c := 0; c < blCount[l]; c++ {
+			lengths[order[idx]] = l
+			idx++
+		}
+	}
+}
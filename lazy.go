@@ -0,0 +1,95 @@
+package lzss
+
+// runLZSSLazy implements DEFLATE/zlib-style lazy matching on top of the
+// same binary-tree search runLZSS's greedy path uses: after finding a
+// match at position r, it also inserts and searches position r+1 before
+// committing, and only takes the match at r if the one at r+1 is not
+// strictly longer. When it isn't, the position at r is emitted as a
+// literal and the longer match takes its place as the new candidate.
+func runLZSSLazy(e *LZSSEncoder, data []byte, emit func(isMatch bool, lit byte, pos, length int)) {
+	r := e.N - e.F
+	dataPos := 0
+	length := 0
+	for length < e.F && dataPos < len(data) {
+		e.textBuf[r+length] = data[dataPos]
+		dataPos++
+		length++
+	}
+	if length == 0 {
+		return
+	}
+
+	for i := 1; i <= e.F; i++ {
+		e.insertNode(r - i)
+	}
+	e.insertNode(r)
+
+	s := 0
+
+	// advance steps the ring buffer and tree forward by n positions,
+	// exactly like the inner consumption loops in runLZSS's greedy path,
+	// but one position at a time so a fresh match can be searched for at
+	// every position in between.
+	advance := func(n int) {
+		for k := 0; k < n; k++ {
+			e.deleteNode(s)
+			if dataPos < len(data) {
+				c := data[dataPos]
+				dataPos++
+				e.textBuf[s] = c
+				if s < e.F-1 {
+					e.textBuf[s+e.N] = c
+				}
+				s = (s + 1) & (e.N - 1)
+				r = (r + 1) & (e.N - 1)
+				e.insertNode(r)
+			} else {
+				s = (s + 1) & (e.N - 1)
+				r = (r + 1) & (e.N - 1)
+				length--
+				if length > 0 {
+					e.insertNode(r)
+				}
+			}
+		}
+	}
+
+	havePrev := false
+	prevLen, prevPos := 0, 0
+	var prevLit byte
+
+	for length > 0 || havePrev {
+		curLen, curPos := 0, 0
+		var curLit byte
+		if length > 0 {
+			curLen = e.matchLength
+			if curLen > length {
+				curLen = length
+			}
+			curPos = e.matchPosition
+			curLit = e.textBuf[r]
+			if curLen <= e.THRESHOLD {
+				curLen = 0
+			}
+		}
+
+		if havePrev {
+			if prevLen > e.THRESHOLD && curLen <= prevLen {
+				emit(true, 0, prevPos, prevLen)
+				havePrev = false
+				advance(prevLen - 1)
+				continue
+			}
+			emit(false, prevLit, 0, 0)
+			havePrev = false
+		}
+
+		if length == 0 {
+			break
+		}
+
+		havePrev = true
+		prevLen, prevPos, prevLit = curLen, curPos, curLit
+		advance(1)
+	}
+}
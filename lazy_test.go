@@ -0,0 +1,62 @@
+package lzss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStrategyRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 200)
+
+	for _, strategy := range []Strategy{StrategyGreedy, StrategyLazy, StrategyHuffmanOnly} {
+		settings := DefaultSettings()
+		settings.Strategy = strategy
+
+		compressed := Compress(data, settings)
+		got := Decompress(compressed, settings)
+		if !bytes.Equal(got, data) {
+			t.Fatalf("strategy %v: round trip mismatch (got %d bytes, want %d)", strategy, len(got), len(data))
+		}
+	}
+}
+
+// TestStrategyHuffmanOnlyEmitsNoMatches checks that StrategyHuffmanOnly
+// really never emits a back-reference: every flag bit in the fixed-field
+// output must be 1 (literal).
+func TestStrategyHuffmanOnlyEmitsNoMatches(t *testing.T) {
+	data := bytes.Repeat([]byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), 50)
+	settings := DefaultSettings()
+	settings.Strategy = StrategyHuffmanOnly
+
+	compressed := Compress(data, settings)
+
+	pos := 0
+	for pos < len(compressed) {
+		flags := compressed[pos]
+		pos++
+		for mask := byte(1); mask != 0 && pos < len(compressed); mask <<= 1 {
+			if flags&mask == 0 {
+				t.Fatalf("StrategyHuffmanOnly emitted a match token at output byte %d", pos)
+			}
+			pos++
+		}
+	}
+}
+
+// TestMaxChainLengthBound checks that bounding insertNode's search still
+// produces correctly round-trippable output, on data repetitive enough
+// that a bounded chain search will actually stop short of the best match.
+func TestMaxChainLengthBound(t *testing.T) {
+	data := bytes.Repeat([]byte("abcdefgh"), 2000)
+
+	for _, maxChain := range []int{0, 1, 4} {
+		settings := DefaultSettings()
+		settings.MaxChainLength = maxChain
+
+		compressed := Compress(data, settings)
+		got := Decompress(compressed, settings)
+		if !bytes.Equal(got, data) {
+			t.Fatalf("MaxChainLength %d: round trip mismatch (got %d bytes, want %d)", maxChain, len(got), len(data))
+		}
+	}
+}
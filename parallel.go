@@ -0,0 +1,294 @@
+package lzss
+
+import (
+	"encoding/binary"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// Content-defined chunking: a Rabin-style rolling hash over a sliding
+// window of cdcWindow bytes picks block boundaries independent of the
+// surrounding byte offsets, so inserting or deleting bytes near the
+// start of the input only reshuffles the chunks it touches rather than
+// every chunk after it, unlike fixed-size splitting.
+const (
+	cdcWindow = 64
+	cdcBase   = 1099511628211 // FNV-prime-sized multiplier, keeps the hash well mixed
+	// cdcMagic is the value the masked hash is compared against to cut a
+	// chunk. Zero is the conventional choice (as used by rsync's rolling
+	// checksum and most practical CDC implementations).
+	cdcMagic = 0
+)
+
+// cdcPow is cdcBase^cdcWindow, the weight of the byte leaving the rolling
+// window, i.e. h = h*cdcBase + in - out*cdcPow.
+var cdcPow = func() uint64 {
+	p := uint64(1)
+	for i := 0; i < cdcWindow; i++ {
+		p *= cdcBase
+	}
+	return p
+}()
+
+// ParallelOptions configures CompressParallel, DecompressParallel and
+// ParallelWriter. A zero value is valid; unset fields (<= 0) fall back to
+// their defaults.
+type ParallelOptions struct {
+	TargetBlockSize int // default 256 KiB
+	MinBlockSize    int // default 64 KiB
+	MaxBlockSize    int // default 1 MiB
+	Workers         int // default runtime.GOMAXPROCS(0)
+}
+
+func (opts ParallelOptions) withDefaults() ParallelOptions {
+	if opts.TargetBlockSize <= 0 {
+		opts.TargetBlockSize = 256 * 1024
+	}
+	if opts.MinBlockSize <= 0 {
+		opts.MinBlockSize = 64 * 1024
+	}
+	if opts.MaxBlockSize <= 0 {
+		opts.MaxBlockSize = 1024 * 1024
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	return opts
+}
+
+// chunkMask returns a bitmask whose size makes an even coin-flip cut
+// probability land, on average, every target bytes: the smallest
+// power-of-two minus one that is at least target.
+func chunkMask(target int) uint64 {
+	mask := uint64(1)
+	for mask < uint64(target) {
+		mask <<= 1
+	}
+	return mask - 1
+}
+
+// findChunkBoundaries splits data into content-defined blocks: it rolls
+// a hash over a cdcWindow-byte window and cuts whenever the hash, masked
+// to opts.TargetBlockSize, equals cdcMagic, as long as the block is
+// already at least opts.MinBlockSize; it force-cuts at opts.MaxBlockSize
+// regardless. The returned offsets are exclusive block ends, so
+// data[boundaries[i-1]:boundaries[i]] is the i-th block.
+func findChunkBoundaries(data []byte, opts ParallelOptions) []int {
+	if len(data) == 0 {
+		return nil
+	}
+	mask := chunkMask(opts.TargetBlockSize)
+
+	var bounds []int
+	start := 0
+	var h uint64
+	for i, b := range data {
+		h = h*cdcBase + uint64(b)
+		if i >= cdcWindow {
+			h -= uint64(data[i-cdcWindow]) * cdcPow
+		}
+
+		size := i - start + 1
+		atCut := i >= cdcWindow-1 && h&mask == cdcMagic
+		if size >= opts.MaxBlockSize || (size >= opts.MinBlockSize && atCut) {
+			bounds = append(bounds, i+1)
+			start = i + 1
+		}
+	}
+	if start < len(data) {
+		bounds = append(bounds, len(data))
+	}
+	return bounds
+}
+
+// runParallel calls fn(i) for every i in [0, n), running up to workers
+// calls concurrently.
+func runParallel(workers, n int, fn func(i int)) {
+	if n == 0 {
+		return
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// CompressParallel splits data into content-defined blocks per opts,
+// compresses them concurrently across opts.Workers goroutines, and
+// prepends an index of each block's offset, compressed length and
+// uncompressed length to the concatenated compressed blocks.
+func CompressParallel(data []byte, settings *LzssSettings, opts ParallelOptions) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	opts = opts.withDefaults()
+
+	bounds := findChunkBoundaries(data, opts)
+	blocks := make([][]byte, len(bounds))
+	start := 0
+	for i, end := range bounds {
+		blocks[i] = data[start:end]
+		start = end
+	}
+
+	compressed := make([][]byte, len(blocks))
+	runParallel(opts.Workers, len(blocks), func(i int) {
+		compressed[i] = Compress(blocks[i], settings)
+	})
+
+	out := appendUvarint(nil, uint64(len(blocks)))
+	offset := 0
+	for i, c := range compressed {
+		out = appendUvarint(out, uint64(offset))
+		out = appendUvarint(out, uint64(len(c)))
+		out = appendUvarint(out, uint64(len(blocks[i])))
+		offset += len(c)
+	}
+	for _, c := range compressed {
+		out = append(out, c...)
+	}
+	return out
+}
+
+type parallelBlock struct {
+	offset, compLen, rawLen int
+}
+
+// DecompressParallel reverses CompressParallel: it reads the block index,
+// then decompresses the blocks concurrently across opts.Workers
+// goroutines before concatenating them back into the original data. It
+// returns nil if compressedData's block index is malformed or
+// inconsistent with its length, rather than panicking, since none of it
+// is trustworthy until checked - numBlocks and every offset/length in the
+// index are read straight off the wire.
+func DecompressParallel(compressedData []byte, settings *LzssSettings, opts ParallelOptions) []byte {
+	if len(compressedData) == 0 {
+		return nil
+	}
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	opts = opts.withDefaults()
+
+	pos := 0
+	numBlocks, n := binary.Uvarint(compressedData[pos:])
+	if n <= 0 {
+		return nil
+	}
+	pos += n
+
+	// Every index entry is at least three one-byte varints, so numBlocks
+	// can't exceed len(compressedData)/3 without the loop below running
+	// out of bytes first; checking it up front avoids an oversized
+	// make([]parallelBlock, numBlocks) allocation on a bogus count.
+	if numBlocks > uint64(len(compressedData))/3 {
+		return nil
+	}
+
+	idx := make([]parallelBlock, numBlocks)
+	for i := range idx {
+		var off, cl, rl uint64
+		off, n = binary.Uvarint(compressedData[pos:])
+		if n <= 0 {
+			return nil
+		}
+		pos += n
+		cl, n = binary.Uvarint(compressedData[pos:])
+		if n <= 0 {
+			return nil
+		}
+		pos += n
+		rl, n = binary.Uvarint(compressedData[pos:])
+		if n <= 0 {
+			return nil
+		}
+		pos += n
+		if off > uint64(len(compressedData)) || cl > uint64(len(compressedData)) ||
+			rl > (cl+64)*maxFrameExpansionRatio {
+			return nil
+		}
+		idx[i] = parallelBlock{int(off), int(cl), int(rl)}
+	}
+	payload := compressedData[pos:]
+
+	for _, b := range idx {
+		if b.offset < 0 || b.compLen < 0 || b.rawLen < 0 ||
+			b.offset > len(payload) || b.compLen > len(payload)-b.offset {
+			return nil
+		}
+	}
+
+	results := make([][]byte, numBlocks)
+	runParallel(opts.Workers, len(idx), func(i int) {
+		b := idx[i]
+		results[i] = Decompress(payload[b.offset:b.offset+b.compLen], settings)
+	})
+
+	total := 0
+	for _, b := range idx {
+		total += b.rawLen
+	}
+	out := make([]byte, 0, total)
+	for _, r := range results {
+		out = append(out, r...)
+	}
+	return out
+}
+
+// ParallelWriter buffers everything written to it and, on Close,
+// compresses it with CompressParallel and writes the result to the
+// underlying writer.
+type ParallelWriter struct {
+	w        io.Writer
+	settings *LzssSettings
+	opts     ParallelOptions
+	buf      []byte
+	closed   bool
+}
+
+// NewParallelWriter returns a ParallelWriter that writes a
+// CompressParallel-encoded form of everything written to it to w.
+func NewParallelWriter(w io.Writer, settings *LzssSettings, opts ParallelOptions) *ParallelWriter {
+	return &ParallelWriter{w: w, settings: settings, opts: opts}
+}
+
+func (pw *ParallelWriter) Write(p []byte) (int, error) {
+	if pw.closed {
+		return 0, errClosedWriter
+	}
+	pw.buf = append(pw.buf, p...)
+	return len(p), nil
+}
+
+// Close compresses everything written so far and writes it to the
+// underlying writer.
+func (pw *ParallelWriter) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+	_, err := pw.w.Write(CompressParallel(pw.buf, pw.settings, pw.opts))
+	return err
+}
@@ -0,0 +1,72 @@
+package lzss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressDecompressParallelRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 50000)
+	settings := DefaultSettings()
+	opts := ParallelOptions{TargetBlockSize: 64 * 1024, MinBlockSize: 16 * 1024, MaxBlockSize: 128 * 1024, Workers: 4}
+
+	compressed := CompressParallel(data, settings, opts)
+	got := DecompressParallel(compressed, settings, opts)
+	if !bytes.Equal(got, data) {
+		t.Fatalf("round trip mismatch (got %d bytes, want %d)", len(got), len(data))
+	}
+}
+
+// TestDecompressParallelMalformedIndex feeds DecompressParallel a set of
+// corrupt/truncated block indexes - an oversized block count, and
+// offsets/lengths that run past the payload - none of which should panic.
+func TestDecompressParallelMalformedIndex(t *testing.T) {
+	settings := DefaultSettings()
+	opts := ParallelOptions{}
+
+	cases := [][]byte{
+		{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01}, // huge numBlocks varint
+		appendUvarint(nil, 1), // numBlocks=1, index truncated
+		func() []byte {
+			out := appendUvarint(nil, 1)
+			out = appendUvarint(out, 0)   // offset
+			out = appendUvarint(out, 100) // compLen far beyond any payload
+			out = appendUvarint(out, 100) // rawLen
+			return out
+		}(),
+	}
+
+	for i, c := range cases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("case %d: DecompressParallel panicked: %v", i, r)
+				}
+			}()
+			DecompressParallel(c, settings, opts)
+		}()
+	}
+}
+
+// TestDecompressParallelRejectsInflatedRawLen crafts a small index with
+// many empty blocks, each declaring a rawLen near the whole-blob ceiling
+// rather than one scaled to its own (empty) compLen. Summed across many
+// blocks this would ask the aggregation step to preallocate an output
+// slice far larger than the crafted input could ever justify; rawLen must
+// be rejected per-block instead of against the container as a whole.
+func TestDecompressParallelRejectsInflatedRawLen(t *testing.T) {
+	settings := DefaultSettings()
+	opts := ParallelOptions{}
+
+	const numBlocks = 1000
+	out := appendUvarint(nil, numBlocks)
+	for i := 0; i < numBlocks; i++ {
+		out = appendUvarint(out, 0)     // offset
+		out = appendUvarint(out, 0)     // compLen
+		out = appendUvarint(out, 1<<40) // rawLen, wildly out of proportion to compLen
+	}
+
+	if got := DecompressParallel(out, settings, opts); got != nil {
+		t.Fatalf("expected nil for an index with inflated per-block rawLen, got %d bytes", len(got))
+	}
+}
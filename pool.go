@@ -0,0 +1,69 @@
+package lzss
+
+import "sync"
+
+// encoderSignature is the subset of LzssSettings that NewEncoder bakes into
+// an LZSSEncoder: the size of its ring buffer and tree arrays, its padding
+// byte, and its match-search strategy and chain bound. Encoders are
+// pooled per signature rather than per *LzssSettings, since EntropyCoding
+// and FrameInitPos don't affect what Reset needs to rebuild, and settings
+// values are typically constructed fresh per call anyway.
+type encoderSignature struct {
+	frameSize      int
+	frameFill      byte
+	maxMatchLength int
+	minMatchLength int
+	strategy       Strategy
+	maxChainLength int
+}
+
+func signatureOf(settings *LzssSettings) encoderSignature {
+	return encoderSignature{
+		frameSize:      settings.FrameSize,
+		frameFill:      settings.FrameFill,
+		maxMatchLength: settings.MaxMatchLength,
+		minMatchLength: settings.MinMatchLength,
+		strategy:       settings.Strategy,
+		maxChainLength: settings.MaxChainLength,
+	}
+}
+
+// encoderPools holds one *sync.Pool per encoderSignature seen so far, each
+// pool holding LZSSEncoders whose textBuf/lchild/rchild/parent arrays are
+// already sized and ready to Reset.
+//
+// This map is never pruned: every distinct signature a caller passes adds
+// a permanent entry. That's fine for the intended use (a small, mostly
+// fixed set of settings reused across many Compress calls), but callers
+// that vary FrameSize/MaxMatchLength/etc. per call - for example deriving
+// LzssSettings from untrusted FrameReader headers - should not rely on
+// pooling in that case, since it will grow one *sync.Pool per distinct
+// (and possibly attacker-chosen) signature for the life of the process.
+var encoderPools sync.Map // encoderSignature -> *sync.Pool
+
+func poolFor(sig encoderSignature) *sync.Pool {
+	if p, ok := encoderPools.Load(sig); ok {
+		return p.(*sync.Pool)
+	}
+	p, _ := encoderPools.LoadOrStore(sig, new(sync.Pool))
+	return p.(*sync.Pool)
+}
+
+// acquireEncoder returns an LZSSEncoder configured for settings, reusing
+// one pooled under the same encoderSignature (via Reset) when available
+// instead of allocating fresh ring buffer and tree arrays.
+func acquireEncoder(settings *LzssSettings) *LZSSEncoder {
+	if v := poolFor(signatureOf(settings)).Get(); v != nil {
+		e := v.(*LZSSEncoder)
+		e.settings = settings
+		e.Reset()
+		return e
+	}
+	return NewEncoder(settings)
+}
+
+// releaseEncoder returns encoder to the pool for settings' signature, so a
+// later acquireEncoder call with an equivalent signature can reuse it.
+func releaseEncoder(settings *LzssSettings, encoder *LZSSEncoder) {
+	poolFor(signatureOf(settings)).Put(encoder)
+}
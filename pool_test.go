@@ -0,0 +1,100 @@
+package lzss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAppendCompressPreservesDst(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 50)
+	settings := DefaultSettings()
+
+	prefix := []byte("prefix-")
+	dst := append([]byte(nil), prefix...)
+
+	got := AppendCompress(dst, data, settings)
+	if !bytes.HasPrefix(got, prefix) {
+		t.Fatalf("AppendCompress dropped the existing dst prefix")
+	}
+
+	want := Compress(data, settings)
+	if !bytes.Equal(got[len(prefix):], want) {
+		t.Fatalf("AppendCompress's appended bytes (%d) != Compress's output (%d)", len(got)-len(prefix), len(want))
+	}
+
+	// The original dst backing prefix must be untouched.
+	if !bytes.Equal(dst[:len(prefix)], prefix) {
+		t.Fatalf("AppendCompress corrupted the original dst contents")
+	}
+
+	roundTrip := Decompress(got[len(prefix):], settings)
+	if !bytes.Equal(roundTrip, data) {
+		t.Fatalf("round trip through AppendCompress's output mismatch (got %d bytes, want %d)", len(roundTrip), len(data))
+	}
+}
+
+// encodeFixedField drives runLZSS with the same fixed-field token encoding
+// Compress uses, so tests can exercise a specific *LZSSEncoder (for
+// example one that has just been Reset) directly.
+func encodeFixedField(e *LZSSEncoder, data []byte) []byte {
+	codeBuf := make([]byte, 17)
+	compressed := make([]byte, 0)
+
+	codeBuf[0] = 0
+	codeBufPtr := 1
+	mask := uint16(1)
+
+	runLZSS(e, data, func(isMatch bool, lit byte, pos, length int) {
+		if !isMatch {
+			codeBuf[0] |= byte(mask)
+			codeBuf[codeBufPtr] = lit
+			codeBufPtr++
+		} else {
+			codeBuf[codeBufPtr] = byte(pos & 0xFF)
+			codeBufPtr++
+			codeBuf[codeBufPtr] = byte(((pos >> 4) & 0xF0) | (length - (e.THRESHOLD + 1)))
+			codeBufPtr++
+		}
+
+		mask <<= 1
+		if mask == 0x100 {
+			compressed = append(compressed, codeBuf[:codeBufPtr]...)
+			codeBuf[0] = 0
+			codeBufPtr = 1
+			mask = 1
+		}
+	})
+
+	if codeBufPtr > 1 {
+		compressed = append(compressed, codeBuf[:codeBufPtr]...)
+	}
+	return compressed
+}
+
+// TestEncoderResetRoundTrip checks that a manually-constructed encoder,
+// reused via Reset across two unrelated inputs, produces output
+// equivalent to a fresh encoder each time - the property acquireEncoder's
+// pooling depends on.
+func TestEncoderResetRoundTrip(t *testing.T) {
+	settings := DefaultSettings()
+	first := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 50)
+	second := bytes.Repeat([]byte("a different payload, repeated many times. "), 50)
+
+	e := NewEncoder(settings)
+	compressed1 := encodeFixedField(e, first)
+	if got := Decompress(compressed1, settings); !bytes.Equal(got, first) {
+		t.Fatalf("first pass: round trip mismatch (got %d bytes, want %d)", len(got), len(first))
+	}
+
+	e.Reset()
+	compressed2 := encodeFixedField(e, second)
+	if got := Decompress(compressed2, settings); !bytes.Equal(got, second) {
+		t.Fatalf("second pass after Reset: round trip mismatch (got %d bytes, want %d)", len(got), len(second))
+	}
+
+	fresh := NewEncoder(settings)
+	want := encodeFixedField(fresh, second)
+	if !bytes.Equal(compressed2, want) {
+		t.Fatalf("Reset encoder's output (%d bytes) != a fresh NewEncoder's output (%d bytes) for the same input", len(compressed2), len(want))
+	}
+}
@@ -0,0 +1,158 @@
+package lzss
+
+import (
+	"bufio"
+	"io"
+)
+
+// Resetter resets a Reader to read from a new source, avoiding an
+// allocation. Every io.Reader returned by NewReader implements Resetter,
+// which makes pooling decoders (for example via sync.Pool) practical.
+type Resetter interface {
+	Reset(r io.Reader, settings *LzssSettings) error
+}
+
+// reader decodes an LZSS bitstream incrementally, mirroring the shape of
+// compress/flate's decompressor.
+type reader struct {
+	br       *bufio.Reader
+	settings *LzssSettings
+	N        int
+	F        int
+
+	textBuf []byte
+	pos     int
+	flags   uint16
+
+	out []byte // decoded bytes not yet returned to the caller
+	err error
+}
+
+// NewReader returns an io.Reader that decompresses LZSS data read from r,
+// using settings (or DefaultSettings if settings is nil) to reconstruct the
+// ring buffer parameters. The returned value also implements Resetter.
+func NewReader(r io.Reader, settings *LzssSettings) io.Reader {
+	z := &reader{}
+	z.Reset(r, settings)
+	return z
+}
+
+// Reset discards the reader's state and makes it equivalent to the result
+// of NewReader, but reading from r with settings instead.
+func (z *reader) Reset(r io.Reader, settings *LzssSettings) error {
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+
+	if br, ok := r.(*bufio.Reader); ok {
+		z.br = br
+	} else {
+		z.br = bufio.NewReader(r)
+	}
+	z.settings = settings
+	z.N = settings.FrameSize
+	z.F = settings.MaxMatchLength
+
+	size := z.N + z.F - 1
+	if cap(z.textBuf) < size {
+		z.textBuf = make([]byte, size)
+	} else {
+		z.textBuf = z.textBuf[:size]
+	}
+	for i := range z.textBuf {
+		z.textBuf[i] = settings.FrameFill
+	}
+
+	z.pos = z.N - z.F
+	z.flags = 0
+	z.out = z.out[:0]
+	z.err = nil
+	return nil
+}
+
+// Read decompresses as much of the underlying stream as needed to satisfy
+// p, returning io.EOF once the final flag group has been consumed and all
+// decoded bytes have been returned.
+func (z *reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for len(z.out) == 0 && z.err == nil {
+		z.decodeStep()
+	}
+	if len(z.out) == 0 {
+		return 0, z.err
+	}
+
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
+
+// decodeStep decodes a single flag bit's worth of output - one literal byte
+// or one back-reference - and appends it to z.out. On EOF or a read error
+// it records z.err and leaves z.out unchanged.
+func (z *reader) decodeStep() {
+	THRESHOLD := z.settings.MinMatchLength
+
+	z.flags >>= 1
+	if z.flags&0x100 == 0 {
+		b, err := z.br.ReadByte()
+		if err != nil {
+			z.err = err
+			return
+		}
+		z.flags = uint16(b) | 0xFF00
+	}
+
+	if z.flags&1 != 0 {
+		c, err := z.br.ReadByte()
+		if err != nil {
+			// The flag byte's unused high bits (when the final group has
+			// fewer than 8 tokens) are always 0, i.e. "match", so a set
+			// bit always announces a real literal - running out here is a
+			// truncated stream, not the normal end of one.
+			z.err = unexpectedEOF(err)
+			return
+		}
+		z.out = append(z.out, c)
+		z.textBuf[z.pos] = c
+		z.pos = (z.pos + 1) & (z.N - 1)
+		return
+	}
+
+	i, err := z.br.ReadByte()
+	if err != nil {
+		z.err = err
+		return
+	}
+	j, err := z.br.ReadByte()
+	if err != nil {
+		// Unlike the first byte, having read one byte of a match token
+		// means a real token was in progress, so running out here is a
+		// genuinely truncated stream.
+		z.err = unexpectedEOF(err)
+		return
+	}
+
+	matchPos := int(i) | ((int(j) & 0xF0) << 4)
+	matchLen := (int(j) & 0x0F) + THRESHOLD
+
+	for k := 0; k <= matchLen; k++ {
+		c := z.textBuf[(matchPos+k)&(z.N-1)]
+		z.out = append(z.out, c)
+		z.textBuf[z.pos] = c
+		z.pos = (z.pos + 1) & (z.N - 1)
+	}
+}
+
+// unexpectedEOF turns a clean io.EOF encountered mid-token into
+// io.ErrUnexpectedEOF, since a flag group promised more bytes than the
+// stream delivered.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,211 @@
+package lzss
+
+import (
+	"errors"
+	"io"
+)
+
+// errClosedWriter is returned by Write after Close has been called.
+var errClosedWriter = errors.New("lzss: write to closed Writer")
+
+// Writer is an io.WriteCloser that LZSS-compresses data written to it and
+// writes the compressed bytes to an underlying io.Writer. It mirrors the
+// shape of compress/gzip.Writer and compress/flate.Writer: successive
+// Write calls feed the same ring buffer and binary trees, so matches can
+// span across calls, and Close flushes any partial 8-flag group.
+type Writer struct {
+	w        io.Writer
+	settings *LzssSettings
+	enc      *LZSSEncoder
+
+	s, r, length int
+	primed       bool
+	closed       bool
+	advRemaining int
+
+	codeBuf    [17]byte
+	codeBufPtr int
+	mask       uint16
+
+	pending []byte // written bytes not yet folded into the ring buffer
+	err     error
+}
+
+// NewWriter returns a Writer that compresses data written to it and writes
+// the result to w, using settings (or DefaultSettings if settings is nil).
+func NewWriter(w io.Writer, settings *LzssSettings) *Writer {
+	if settings == nil {
+		settings = DefaultSettings()
+	}
+	wr := &Writer{enc: NewEncoder(settings), settings: settings}
+	wr.Reset(w)
+	return wr
+}
+
+// Reset discards the Writer's state and makes it equivalent to the result
+// of NewWriter, but writing to w instead. This permits reusing a Writer
+// (for example from a sync.Pool) instead of allocating a new one, since the
+// ring buffer and tree arrays in LZSSEncoder are expensive to reallocate.
+func (wr *Writer) Reset(w io.Writer) {
+	e := wr.enc
+	for i := range e.textBuf {
+		e.textBuf[i] = e.PADDING
+	}
+	e.initState()
+
+	wr.w = w
+	wr.s = 0
+	wr.r = e.N - e.F
+	wr.length = 0
+	wr.primed = false
+	wr.closed = false
+	wr.advRemaining = 0
+	wr.codeBuf[0] = 0
+	wr.codeBufPtr = 1
+	wr.mask = 1
+	wr.pending = wr.pending[:0]
+	wr.err = nil
+}
+
+// Write compresses p and buffers the result, flushing 8-token groups to the
+// underlying writer as they fill. It never returns a short write unless an
+// error occurred.
+func (wr *Writer) Write(p []byte) (int, error) {
+	if wr.closed {
+		return 0, errClosedWriter
+	}
+	if wr.err != nil {
+		return 0, wr.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	wr.pending = append(wr.pending, p...)
+	if err := wr.encode(false); err != nil {
+		wr.err = err
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close flushes any data still buffered, together with the final partial
+// flag group, to the underlying writer. It does not close the underlying
+// writer.
+func (wr *Writer) Close() error {
+	if wr.closed {
+		return wr.err
+	}
+	wr.closed = true
+	if wr.err != nil {
+		return wr.err
+	}
+
+	if err := wr.encode(true); err != nil {
+		wr.err = err
+		return err
+	}
+	if wr.codeBufPtr > 1 {
+		if _, err := wr.w.Write(wr.codeBuf[:wr.codeBufPtr]); err != nil {
+			wr.err = err
+			return err
+		}
+		wr.codeBuf[0] = 0
+		wr.codeBufPtr = 1
+	}
+	return nil
+}
+
+// encode drives the LZSS state machine as far forward as the currently
+// buffered input allows. With final set, it also performs the draining
+// phase that shrinks the lookahead window once no more input will arrive,
+// exactly as the tail of Compress does. Without final, it pauses (returning
+// nil) as soon as wr.pending is exhausted, preserving enough state to
+// resume on the next Write or on Close.
+func (wr *Writer) encode(final bool) error {
+	e := wr.enc
+
+	if !wr.primed {
+		if len(wr.pending) < e.F && !final {
+			return nil
+		}
+		n := len(wr.pending)
+		if n > e.F {
+			n = e.F
+		}
+		copy(e.textBuf[wr.r:], wr.pending[:n])
+		wr.pending = wr.pending[n:]
+		wr.length = n
+		wr.primed = true
+		if wr.length == 0 {
+			return nil
+		}
+
+		for i := 1; i <= e.F; i++ {
+			e.insertNode(wr.r - i)
+		}
+		e.insertNode(wr.r)
+	}
+
+	for {
+		for wr.advRemaining > 0 {
+			if len(wr.pending) > 0 {
+				c := wr.pending[0]
+				wr.pending = wr.pending[1:]
+				e.deleteNode(wr.s)
+				e.textBuf[wr.s] = c
+				if wr.s < e.F-1 {
+					e.textBuf[wr.s+e.N] = c
+				}
+				wr.s = (wr.s + 1) & (e.N - 1)
+				wr.r = (wr.r + 1) & (e.N - 1)
+				e.insertNode(wr.r)
+				wr.advRemaining--
+			} else if final {
+				e.deleteNode(wr.s)
+				wr.s = (wr.s + 1) & (e.N - 1)
+				wr.r = (wr.r + 1) & (e.N - 1)
+				wr.length--
+				if wr.length > 0 {
+					e.insertNode(wr.r)
+				}
+				wr.advRemaining--
+			} else {
+				return nil
+			}
+		}
+
+		if wr.length <= 0 {
+			return nil
+		}
+
+		if e.matchLength > wr.length {
+			e.matchLength = wr.length
+		}
+
+		if e.matchLength <= e.THRESHOLD {
+			e.matchLength = 1
+			wr.codeBuf[0] |= byte(wr.mask)
+			wr.codeBuf[wr.codeBufPtr] = e.textBuf[wr.r]
+			wr.codeBufPtr++
+		} else {
+			wr.codeBuf[wr.codeBufPtr] = byte(e.matchPosition & 0xFF)
+			wr.codeBufPtr++
+			wr.codeBuf[wr.codeBufPtr] = byte(((e.matchPosition >> 4) & 0xF0) |
+				(e.matchLength - (e.THRESHOLD + 1)))
+			wr.codeBufPtr++
+		}
+
+		wr.mask <<= 1
+		if wr.mask == 0x100 {
+			if _, err := wr.w.Write(wr.codeBuf[:wr.codeBufPtr]); err != nil {
+				return err
+			}
+			wr.codeBuf[0] = 0
+			wr.codeBufPtr = 1
+			wr.mask = 1
+		}
+
+		wr.advRemaining = e.matchLength
+	}
+}
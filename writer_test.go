@@ -0,0 +1,96 @@
+package lzss
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestWriterReaderRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 200)
+	settings := DefaultSettings()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, settings)
+	// Write in small, uneven chunks to exercise matches spanning calls.
+	for i := 0; i < len(data); i += 37 {
+		end := i + 37
+		if end > len(data) {
+			end = len(data)
+		}
+		if _, err := w.Write(data[i:end]); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	got, err := io.ReadAll(NewReader(&buf, settings))
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("round trip mismatch (got %d bytes, want %d)", len(got), len(data))
+	}
+}
+
+// TestWriterMatchesCompress checks that streaming through Writer produces
+// exactly what batch Compress produces for the same input, since Writer
+// is documented to mirror Compress's state machine across calls.
+func TestWriterMatchesCompress(t *testing.T) {
+	data := bytes.Repeat([]byte("abracadabra"), 500)
+	settings := DefaultSettings()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, settings)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	want := Compress(data, settings)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("Writer output (%d bytes) != Compress output (%d bytes)", buf.Len(), len(want))
+	}
+}
+
+// TestReaderTruncatedInput checks that a stream cut off mid-token reports
+// io.ErrUnexpectedEOF rather than hanging or silently returning a short
+// result.
+func TestReaderTruncatedInput(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 200)
+	settings := DefaultSettings()
+	compressed := Compress(data, settings)
+
+	truncated := compressed[:len(compressed)-1]
+	_, err := io.ReadAll(NewReader(bytes.NewReader(truncated), settings))
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+// TestReaderTruncationSweep checks every truncation point of a legitimate
+// stream: the reader must always terminate (no hang) and must never
+// return more bytes than the original input, whether it reports a clean
+// EOF (when the cut landed just before a flag byte or a match token, which
+// reads the same as token-group padding) or io.ErrUnexpectedEOF (when it
+// landed mid-token or before a literal).
+func TestReaderTruncationSweep(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 200)
+	settings := DefaultSettings()
+	compressed := Compress(data, settings)
+
+	for cut := 1; cut < len(compressed); cut++ {
+		truncated := compressed[:len(compressed)-cut]
+		got, err := io.ReadAll(NewReader(bytes.NewReader(truncated), settings))
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatalf("cut %d: unexpected error %v", cut, err)
+		}
+		if len(got) > len(data) {
+			t.Fatalf("cut %d: got %d bytes, more than the original %d", cut, len(got), len(data))
+		}
+	}
+}